@@ -0,0 +1,120 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var targetLabels = []string{"instance", "alias"}
+
+var infoLabels = append(append([]string{}, targetLabels...), "katsubushi_version", "katsubushi_pid")
+
+// KatsubushiCollector implements prometheus.Collector, scraping STATS from
+// every configured katsubushi target on each call to Collect.
+type KatsubushiCollector struct {
+	targets       []Target
+	concurrency   int
+	scrapeTimeout time.Duration
+
+	info           *prometheus.Desc
+	scrapeDuration *prometheus.Desc
+	up             *prometheus.Desc
+	stats          map[string]*prometheus.Desc // keyed by statMetric.statsKey
+}
+
+// NewKatsubushiCollector builds a collector for the given set of targets.
+// scrapeTimeout bounds how long a single target's STATS query may take.
+func NewKatsubushiCollector(targets []Target, concurrency int, scrapeTimeout time.Duration) *KatsubushiCollector {
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	stats := make(map[string]*prometheus.Desc, len(statMetrics))
+	for _, m := range statMetrics {
+		stats[m.statsKey] = prometheus.NewDesc(m.name, m.help, targetLabels, nil)
+	}
+
+	return &KatsubushiCollector{
+		targets:       targets,
+		concurrency:   concurrency,
+		scrapeTimeout: scrapeTimeout,
+		stats:         stats,
+
+		info: prometheus.NewDesc(
+			"katsubushi_info",
+			"Information of katsubushi.",
+			infoLabels, nil,
+		),
+		scrapeDuration: prometheus.NewDesc(
+			"katsubushi_scrape_duration_seconds",
+			"Time it took to scrape the target.",
+			targetLabels, nil,
+		),
+		up: prometheus.NewDesc(
+			"katsubushi_up",
+			"Whether the last scrape of the target succeeded.",
+			targetLabels, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *KatsubushiCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.info
+	ch <- c.scrapeDuration
+	ch <- c.up
+	for _, d := range c.stats {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector, scraping all targets in parallel
+// bounded by c.concurrency.
+func (c *KatsubushiCollector) Collect(ch chan<- prometheus.Metric) {
+	sem := make(chan struct{}, c.concurrency)
+	var wg sync.WaitGroup
+
+	for _, t := range c.targets {
+		t := t
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			c.collectTarget(ch, t)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (c *KatsubushiCollector) collectTarget(ch chan<- prometheus.Metric, t Target) {
+	start := time.Now()
+	instance := t.Addr()
+	labels := []string{instance, t.Alias}
+
+	info, stats, err := getKatsubushiStats(t, c.scrapeTimeout)
+	duration := time.Since(start).Seconds()
+
+	ch <- prometheus.MustNewConstMetric(c.scrapeDuration, prometheus.GaugeValue, duration, labels...)
+
+	if err != nil {
+		logger.Error("scrape failed", "instance", instance, "err", err)
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 0, labels...)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 1, labels...)
+
+	infoValues := append(append([]string{}, labels...), info["version"], info["pid"])
+	ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1, infoValues...)
+
+	for _, m := range statMetrics {
+		v, ok := stats[m.statsKey]
+		if !ok {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.stats[m.statsKey], m.valType, v, labels...)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakeKatsubushiServer starts a TCP listener that replies to every
+// connection with the given canned STATS response.
+func fakeKatsubushiServer(t *testing.T, stats string) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 64)
+				conn.Read(buf)
+				fmt.Fprint(conn, stats)
+			}()
+		}
+	}()
+	return ln
+}
+
+func TestKatsubushiCollectorSuccess(t *testing.T) {
+	ln := fakeKatsubushiServer(t, "STAT pid 123\r\n"+
+		"STAT version 1.0.0\r\n"+
+		"STAT uptime 42\r\n"+
+		"STAT curr_connections 3\r\n"+
+		"STAT cmd_get 10\r\n"+
+		"END\r\n")
+	defer ln.Close()
+
+	host, port, err := net.SplitHostPort(ln.Addr().String())
+	if err != nil {
+		t.Fatalf("SplitHostPort: %v", err)
+	}
+
+	c := NewKatsubushiCollector([]Target{{Host: host, Port: port, Alias: "test"}}, 1, time.Second)
+	instance := net.JoinHostPort(host, port)
+
+	expected := fmt.Sprintf(`
+# HELP katsubushi_up Whether the last scrape of the target succeeded.
+# TYPE katsubushi_up gauge
+katsubushi_up{alias="test",instance=%[1]q} 1
+# HELP katsubushi_curr_connections Current connection.
+# TYPE katsubushi_curr_connections gauge
+katsubushi_curr_connections{alias="test",instance=%[1]q} 3
+# HELP katsubushi_get_cmd_total Number of GET command.
+# TYPE katsubushi_get_cmd_total counter
+katsubushi_get_cmd_total{alias="test",instance=%[1]q} 10
+# HELP katsubushi_uptime_seconds Uptime of katsubushi process.
+# TYPE katsubushi_uptime_seconds gauge
+katsubushi_uptime_seconds{alias="test",instance=%[1]q} 42
+# HELP katsubushi_info Information of katsubushi.
+# TYPE katsubushi_info gauge
+katsubushi_info{alias="test",instance=%[1]q,katsubushi_pid="123",katsubushi_version="1.0.0"} 1
+`, instance)
+
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"katsubushi_up", "katsubushi_curr_connections", "katsubushi_get_cmd_total",
+		"katsubushi_uptime_seconds", "katsubushi_info"); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func TestKatsubushiCollectorScrapeFailure(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	host, port, err := net.SplitHostPort(ln.Addr().String())
+	if err != nil {
+		t.Fatalf("SplitHostPort: %v", err)
+	}
+	ln.Close() // nothing is listening anymore: dialing it must be refused
+
+	c := NewKatsubushiCollector([]Target{{Host: host, Port: port, Alias: "down"}}, 1, time.Second)
+	instance := net.JoinHostPort(host, port)
+
+	expected := fmt.Sprintf(`
+# HELP katsubushi_up Whether the last scrape of the target succeeded.
+# TYPE katsubushi_up gauge
+katsubushi_up{alias="down",instance=%q} 0
+`, instance)
+
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "katsubushi_up"); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+
+	if n := testutil.CollectAndCount(c, "katsubushi_info"); n != 0 {
+		t.Errorf("katsubushi_info metric count = %d, want 0 on a failed scrape", n)
+	}
+}
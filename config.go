@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Target describes a single katsubushi instance to scrape.
+type Target struct {
+	Host  string `yaml:"host"`
+	Port  string `yaml:"port"`
+	Alias string `yaml:"alias"`
+}
+
+// Config is the top level structure of the exporter's YAML config file.
+type Config struct {
+	Concurrency int      `yaml:"concurrency"`
+	Targets     []Target `yaml:"targets"`
+
+	// ProbeAllowlist restricts which "host:port" targets /probe will
+	// accept. An empty list allows any target.
+	ProbeAllowlist []string `yaml:"probe_allowlist"`
+}
+
+// Addr returns the "host:port" dial address of the target.
+func (t Target) Addr() string {
+	return fmt.Sprintf("%s:%s", t.Host, t.Port)
+}
+
+// LoadConfig reads and parses the YAML config file at path.
+func LoadConfig(path string) (*Config, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Config{}
+	if err := yaml.Unmarshal(buf, c); err != nil {
+		return nil, err
+	}
+
+	for i, t := range c.Targets {
+		if t.Host == "" {
+			return nil, fmt.Errorf("targets[%d]: host is required", i)
+		}
+		if t.Port == "" {
+			return nil, fmt.Errorf("targets[%d]: port is required", i)
+		}
+		if t.Alias == "" {
+			c.Targets[i].Alias = t.Addr()
+		}
+	}
+
+	if c.Concurrency <= 0 {
+		c.Concurrency = defaultConcurrency
+	}
+
+	return c, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeConfig(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("writing test config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfigDefaultsAliasAndConcurrency(t *testing.T) {
+	path := writeConfig(t, `
+targets:
+  - host: katsubushi1.example.com
+    port: "11212"
+  - host: katsubushi2.example.com
+    port: "11212"
+    alias: custom-alias
+`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if got, want := cfg.Targets[0].Alias, "katsubushi1.example.com:11212"; got != want {
+		t.Errorf("Targets[0].Alias = %q, want %q", got, want)
+	}
+	if got, want := cfg.Targets[1].Alias, "custom-alias"; got != want {
+		t.Errorf("Targets[1].Alias = %q, want %q", got, want)
+	}
+	if got, want := cfg.Concurrency, defaultConcurrency; got != want {
+		t.Errorf("Concurrency = %d, want default %d", got, want)
+	}
+}
+
+func TestLoadConfigMissingHostOrPort(t *testing.T) {
+	cases := []string{
+		`targets:
+  - port: "11212"`,
+		`targets:
+  - host: katsubushi1.example.com`,
+	}
+
+	for _, c := range cases {
+		path := writeConfig(t, c)
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("LoadConfig(%q) error = nil, want error for missing host/port", c)
+		}
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "does-not-exist.yml")); err == nil {
+		t.Fatal("LoadConfig() error = nil, want error for missing file")
+	}
+}
+
+func TestLoadConfigNoTargets(t *testing.T) {
+	path := writeConfig(t, `probe_allowlist:
+  - katsubushi1.example.com:11212
+`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(cfg.Targets) != 0 {
+		t.Errorf("Targets = %v, want empty", cfg.Targets)
+	}
+	if len(cfg.ProbeAllowlist) != 1 {
+		t.Errorf("ProbeAllowlist = %v, want 1 entry", cfg.ProbeAllowlist)
+	}
+}
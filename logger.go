@@ -0,0 +1,41 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+)
+
+// logger is the exporter's process-wide structured logger, configured in
+// main from the --log.level / --log.format flags.
+var logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+// setupLogger builds a slog.Logger for the given level (debug, info, warn,
+// error) and format (logfmt, json).
+func setupLogger(level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var handler slog.Handler
+	switch format {
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	case "logfmt", "":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	default:
+		return nil, &unknownLogFormatError{format}
+	}
+
+	return slog.New(handler), nil
+}
+
+type unknownLogFormatError struct {
+	format string
+}
+
+func (e *unknownLogFormatError) Error() string {
+	return "unknown log format: " + e.format
+}
@@ -1,26 +1,27 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"flag"
-	"fmt"
-	"net"
 	"net/http"
-	"strconv"
-	"strings"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"github.com/prometheus/common/log"
+	"github.com/prometheus/exporter-toolkit/web"
 )
 
 const (
-	defaultMetricsInterval = 30
-	defaultAddr            = ":9298"
-	defaultKatsubushiHost  = "localhost"
-	defaultKatsubushiPort  = "11212"
-	retryDuration          = 1
+	defaultAddr          = ":9298"
+	defaultConcurrency   = 5
+	defaultScrapeTimeout = 5 * time.Second
+	defaultLogLevel      = "info"
+	defaultLogFormat     = "logfmt"
+	shutdownTimeout      = 10 * time.Second
 )
 
 const rootDoc = `<html>
@@ -28,163 +29,83 @@ const rootDoc = `<html>
 <body>
 <h1>katsubushi Exporter</h1>
 <p><a href="/metrics">Metrics</a></p>
+<p><a href="/probe?target=localhost:11212">Probe</a></p>
 </body>
 </html>
 `
 
 var addr = flag.String("listen-address", defaultAddr, "The address to listen on for HTTP requests.")
-var metricsInterval = flag.Int("metricsInterval", defaultMetricsInterval, "Interval to scrape katsubushi stats.")
-var katsubushiHost = flag.String("katsubushiHost", defaultKatsubushiHost, "target katsubushi host.")
-var katsubushiPort = flag.String("katsubushiPort", defaultKatsubushiPort, "target katsubushi port.")
+var configFile = flag.String("config", "katsubushi-exporter.yml", "Path to the YAML config file listing katsubushi targets. Its probe_allowlist restricts /probe; leaving it empty lets any caller use this exporter to probe arbitrary host:port targets reachable from it.")
+var scrapeTimeout = flag.Duration("scrape-timeout", defaultScrapeTimeout, "Per-target timeout for the STATS query.")
+var logLevel = flag.String("log.level", defaultLogLevel, "Log level (debug, info, warn, error).")
+var logFormat = flag.String("log.format", defaultLogFormat, "Log format (logfmt, json).")
+var webConfigFile = flag.String("web.config.file", "", "[EXPERIMENTAL] Path to a web config file that can enable TLS or basic auth, in the format used by the Prometheus exporter-toolkit. Plain HTTP is used when unset.")
 
-var infoLabels = []string{
-	"katsubushi_version",
-	"katsubushi_pid",
-}
+func main() {
+	flag.Parse()
 
-var labels = []string{}
-
-var (
-	katsubushiInfo = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "katsubushi_info",
-			Help: "Information of katsubushi.",
-		},
-		infoLabels,
-	)
-
-	katsubushiUptime = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "katsubushi_uptime",
-			Help: "Uptime of katsubushi process.",
-		},
-		labels,
-	)
-
-	katsubushiCurrConnections = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "katsubushi_curr_connections",
-			Help: "Current connection.",
-		},
-		labels,
-	)
-
-	katsubushiTotalConnections = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "katsubushi_total_connections",
-			Help: "Total connection.",
-		},
-		labels,
-	)
-
-	katsubushiCmdGet = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "katsubushi_get_cmd",
-			Help: "Number of GET command.",
-		},
-		labels,
-	)
-
-	katsubushiGetHits = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "katsubushi_get_hits",
-			Help: "Number of Get command success.",
-		},
-		labels,
-	)
-
-	katsubushiGetMisses = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "katsubushi_get_misses",
-			Help: "Number of Get command miss.",
-		},
-		labels,
-	)
-)
+	l, err := setupLogger(*logLevel, *logFormat)
+	if err != nil {
+		logger.Error("invalid logging flags", "err", err)
+		os.Exit(1)
+	}
+	logger = l
 
-func init() {
-	prometheus.MustRegister(katsubushiInfo)
-	prometheus.MustRegister(katsubushiUptime)
-	prometheus.MustRegister(katsubushiCurrConnections)
-	prometheus.MustRegister(katsubushiTotalConnections)
-	prometheus.MustRegister(katsubushiCmdGet)
-	prometheus.MustRegister(katsubushiGetHits)
-	prometheus.MustRegister(katsubushiGetMisses)
-}
+	logger.Info("starting katsubushi exporter")
 
-func getKatsubushiStats() (map[string]string, map[string]float64, error) {
-	network := "tcp"
-	target := fmt.Sprintf("%s:%s", *katsubushiHost, *katsubushiPort)
-	conn, err := net.Dial(network, target)
+	cfg, err := LoadConfig(*configFile)
 	if err != nil {
-		return nil, nil, err
+		logger.Error("failed to load config", "file", *configFile, "err", err)
+		os.Exit(1)
+	}
+	if len(cfg.ProbeAllowlist) == 0 {
+		logger.Warn("probe_allowlist is empty: /probe will dial any host:port target given to it, turning this exporter into an open TCP-connect oracle on its network")
 	}
-	defer conn.Close()
 
-	fmt.Fprint(conn, "STATS\r\n")
+	collector := NewKatsubushiCollector(cfg.Targets, cfg.Concurrency, *scrapeTimeout)
+	prometheus.MustRegister(collector)
 
-	info := map[string]string{}
-	stats := map[string]float64{}
-	sc := bufio.NewScanner(conn)
-	for sc.Scan() {
-		s := sc.Text()
-		if s == "END" {
-			break
-		}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/probe", probeHandler(cfg, *scrapeTimeout))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(rootDoc))
+	})
 
-		res := strings.Split(s, " ")
-		if res[0] == "STAT" {
-			if res[1] == "pid" || res[1] == "version" {
-				info[res[1]] = res[2]
-			} else {
-				if f, err := strconv.ParseFloat(res[2], 64); err == nil {
-					stats[res[1]] = f
-				} else {
-					return nil, nil, err
-				}
-			}
-		}
-	}
-	err = sc.Err()
-	return info, stats, err
-}
+	srv := &http.Server{Addr: *addr, Handler: mux}
 
-func main() {
-	flag.Parse()
-	log.Info("start katsubushi exporter")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	webFlags := &web.FlagConfig{
+		WebListenAddresses: &[]string{*addr},
+		WebConfigFile:      webConfigFile,
+	}
 
+	serveErr := make(chan error, 1)
 	go func() {
-		for {
-			info, stats, err := getKatsubushiStats()
-			if err != nil {
-				log.Errorln(err)
-				time.Sleep(time.Duration(retryDuration) * time.Second)
-				continue
-			}
-			if info["version"] == "" || info["pid"] == "" {
-				log.Info("Retry since info(version or pid) is empty")
-				time.Sleep(time.Duration(retryDuration) * time.Second)
-				continue
-			}
-			infoLabel := prometheus.Labels{
-				"katsubushi_version": info["version"],
-				"katsubushi_pid":     info["pid"],
-			}
-			label := prometheus.Labels{}
-			katsubushiInfo.With(infoLabel).Set(float64(1))
-			katsubushiUptime.With(label).Set(stats["uptime"])
-			katsubushiCurrConnections.With(label).Set(stats["curr_connections"])
-			katsubushiTotalConnections.With(label).Set(stats["total_connections"])
-			katsubushiCmdGet.With(label).Set(stats["cmd_get"])
-			katsubushiGetHits.With(label).Set(stats["get_hits"])
-			katsubushiGetMisses.With(label).Set(stats["get_misses"])
-			time.Sleep(time.Duration(*metricsInterval) * time.Second)
-		}
+		logger.Info("listening", "address", *addr)
+		serveErr <- web.ListenAndServe(srv, webFlags, logger)
 	}()
-	http.Handle("/metrics", promhttp.Handler())
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte(rootDoc))
-	})
 
-	log.Fatal(http.ListenAndServe(*addr, nil))
+	select {
+	case err := <-serveErr:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("server error", "err", err)
+			os.Exit(1)
+		}
+	case <-ctx.Done():
+		stop()
+		logger.Info("shutting down")
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logger.Error("graceful shutdown failed", "err", err)
+			os.Exit(1)
+		}
+	}
+
+	logger.Info("exporter stopped")
 }
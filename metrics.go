@@ -0,0 +1,36 @@
+package main
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// statMetric declares one exported metric derived from a single STATS key.
+// Adding support for a new STATS field is a matter of adding one entry here.
+type statMetric struct {
+	statsKey string
+	name     string
+	help     string
+	valType  prometheus.ValueType
+}
+
+// statMetrics is the full set of katsubushi/memcached-protocol STATS fields
+// this exporter surfaces. Fields that only ever increase are registered as
+// Counter, everything else (point-in-time state) as Gauge.
+var statMetrics = []statMetric{
+	{"uptime", "katsubushi_uptime_seconds", "Uptime of katsubushi process.", prometheus.GaugeValue},
+	{"time", "katsubushi_server_time_seconds", "Current UNIX time according to the server.", prometheus.GaugeValue},
+	{"curr_connections", "katsubushi_curr_connections", "Current connection.", prometheus.GaugeValue},
+	{"threads", "katsubushi_threads", "Number of threads used by the current katsubushi process.", prometheus.GaugeValue},
+	{"accepting_conns", "katsubushi_accepting_connections", "Whether the server is currently accepting connections (0/1).", prometheus.GaugeValue},
+
+	{"total_connections", "katsubushi_total_connections_total", "Total connection.", prometheus.CounterValue},
+	{"cmd_get", "katsubushi_get_cmd_total", "Number of GET command.", prometheus.CounterValue},
+	{"get_hits", "katsubushi_get_hits_total", "Number of Get command success.", prometheus.CounterValue},
+	{"get_misses", "katsubushi_get_misses_total", "Number of Get command miss.", prometheus.CounterValue},
+	{"bytes_read", "katsubushi_bytes_read_total", "Total bytes read by this server from network.", prometheus.CounterValue},
+	{"bytes_written", "katsubushi_bytes_written_total", "Total bytes sent by this server to network.", prometheus.CounterValue},
+	{"listen_disabled_num", "katsubushi_listen_disabled_total", "Number of times the server has stopped accepting new connections.", prometheus.CounterValue},
+	{"rusage_user", "katsubushi_rusage_user_seconds_total", "Accumulated user time for this process.", prometheus.CounterValue},
+	{"rusage_system", "katsubushi_rusage_system_seconds_total", "Accumulated system time for this process.", prometheus.CounterValue},
+
+	{"id_generate_count", "katsubushi_id_generated_total", "Total number of IDs generated.", prometheus.CounterValue},
+	{"id_generate_error_count", "katsubushi_id_generate_errors_total", "Total number of ID generation errors.", prometheus.CounterValue},
+}
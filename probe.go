@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// probeAllowed reports whether target may be probed given the config's
+// allowlist. An empty allowlist permits any target.
+func (c *Config) probeAllowed(target string) bool {
+	if len(c.ProbeAllowlist) == 0 {
+		return true
+	}
+	for _, a := range c.ProbeAllowlist {
+		if a == target {
+			return true
+		}
+	}
+	return false
+}
+
+// probeHandler returns an http.HandlerFunc implementing blackbox-exporter
+// style on-demand probing: ?target=host:port is scraped once and its
+// metrics are rendered through a fresh, per-request registry.
+func probeHandler(cfg *Config, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		target := r.URL.Query().Get("target")
+		if target == "" {
+			http.Error(w, "target parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		host, port, err := net.SplitHostPort(target)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid target %q: %v", target, err), http.StatusBadRequest)
+			return
+		}
+
+		if !cfg.probeAllowed(target) {
+			http.Error(w, fmt.Sprintf("target %q is not allowlisted for probing", target), http.StatusForbidden)
+			return
+		}
+
+		registry := prometheus.NewRegistry()
+		probeSuccess := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "katsubushi_probe_success",
+			Help: "Whether the probe of the target succeeded.",
+		})
+		probeDuration := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "katsubushi_probe_duration_seconds",
+			Help: "Time it took to probe the target.",
+		})
+		registry.MustRegister(probeSuccess, probeDuration)
+
+		t := Target{Host: host, Port: port, Alias: target}
+
+		start := time.Now()
+		info, stats, err := getKatsubushiStats(t, timeout)
+		probeDuration.Set(time.Since(start).Seconds())
+
+		if err != nil {
+			logger.Error("probe failed", "target", target, "err", err)
+			probeSuccess.Set(0)
+		} else {
+			probeSuccess.Set(1)
+			registerProbeStats(registry, info, stats)
+		}
+
+		promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	}
+}
+
+// registerProbeStats registers the scraped info/stats as unlabelled metrics
+// on registry, one probe target at a time, using the same statMetrics table
+// the fleet collector uses.
+func registerProbeStats(registry *prometheus.Registry, info map[string]string, stats map[string]float64) {
+	if info["version"] != "" || info["pid"] != "" {
+		probeInfo := prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "katsubushi_info",
+				Help: "Information of katsubushi.",
+			},
+			[]string{"katsubushi_version", "katsubushi_pid"},
+		)
+		registry.MustRegister(probeInfo)
+		probeInfo.With(prometheus.Labels{
+			"katsubushi_version": info["version"],
+			"katsubushi_pid":     info["pid"],
+		}).Set(1)
+	}
+
+	for _, m := range statMetrics {
+		v, ok := stats[m.statsKey]
+		if !ok {
+			continue
+		}
+
+		if m.valType == prometheus.CounterValue {
+			c := prometheus.NewCounter(prometheus.CounterOpts{Name: m.name, Help: m.help})
+			registry.MustRegister(c)
+			c.Add(v)
+			continue
+		}
+
+		g := prometheus.NewGauge(prometheus.GaugeOpts{Name: m.name, Help: m.help})
+		registry.MustRegister(g)
+		g.Set(v)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigProbeAllowed(t *testing.T) {
+	cases := []struct {
+		name      string
+		allowlist []string
+		target    string
+		want      bool
+	}{
+		{"empty allowlist allows anything", nil, "katsubushi1.example.com:11212", true},
+		{"listed target allowed", []string{"katsubushi1.example.com:11212"}, "katsubushi1.example.com:11212", true},
+		{"unlisted target denied", []string{"katsubushi1.example.com:11212"}, "katsubushi2.example.com:11212", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg := &Config{ProbeAllowlist: c.allowlist}
+			if got := cfg.probeAllowed(c.target); got != c.want {
+				t.Errorf("probeAllowed(%q) = %v, want %v", c.target, got, c.want)
+			}
+		})
+	}
+}
+
+func probeRequest(target string) *http.Request {
+	url := "/probe"
+	if target != "" {
+		url += "?target=" + target
+	}
+	return httptest.NewRequest(http.MethodGet, url, nil)
+}
+
+func TestProbeHandlerMissingTarget(t *testing.T) {
+	rec := httptest.NewRecorder()
+	probeHandler(&Config{}, time.Second).ServeHTTP(rec, probeRequest(""))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestProbeHandlerMalformedTarget(t *testing.T) {
+	rec := httptest.NewRecorder()
+	probeHandler(&Config{}, time.Second).ServeHTTP(rec, probeRequest("not-a-host-port"))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestProbeHandlerDisallowedTarget(t *testing.T) {
+	cfg := &Config{ProbeAllowlist: []string{"allowed.example.com:11212"}}
+
+	rec := httptest.NewRecorder()
+	probeHandler(cfg, time.Second).ServeHTTP(rec, probeRequest("other.example.com:11212"))
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestProbeHandlerSuccess(t *testing.T) {
+	ln := fakeKatsubushiServer(t, "STAT pid 123\r\n"+
+		"STAT version 1.0.0\r\n"+
+		"STAT uptime 42\r\n"+
+		"END\r\n")
+	defer ln.Close()
+
+	rec := httptest.NewRecorder()
+	probeHandler(&Config{}, time.Second).ServeHTTP(rec, probeRequest(ln.Addr().String()))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d, body:\n%s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "katsubushi_probe_success 1") {
+		t.Errorf("body missing katsubushi_probe_success 1:\n%s", rec.Body.String())
+	}
+}
+
+func TestProbeHandlerScrapeFailure(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close() // nothing is listening anymore: dialing it must be refused
+
+	rec := httptest.NewRecorder()
+	probeHandler(&Config{}, time.Second).ServeHTTP(rec, probeRequest(addr))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d, body:\n%s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "katsubushi_probe_success 0") {
+		t.Errorf("body missing katsubushi_probe_success 0:\n%s", rec.Body.String())
+	}
+}
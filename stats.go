@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// getKatsubushiStats connects to the given target, issues STATS and parses
+// the response into string info fields (version, pid) and numeric stats.
+// timeout bounds both the dial and the read of the full STATS response.
+func getKatsubushiStats(t Target, timeout time.Duration) (map[string]string, map[string]float64, error) {
+	conn, err := net.DialTimeout("tcp", t.Addr(), timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, nil, err
+	}
+
+	fmt.Fprint(conn, "STATS\r\n")
+
+	return parseStats(conn)
+}
+
+// parseStats reads a memcached-protocol STATS response (terminated by an
+// "END" line) from r, splitting it into string info fields (version, pid)
+// and numeric stats. A STAT line whose value isn't a valid number is an
+// error, since that indicates either a protocol mismatch or a STATS field
+// this exporter doesn't know how to categorize yet.
+func parseStats(r io.Reader) (map[string]string, map[string]float64, error) {
+	info := map[string]string{}
+	stats := map[string]float64{}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		s := sc.Text()
+		if s == "END" {
+			break
+		}
+
+		res := strings.Split(s, " ")
+		if len(res) < 3 || res[0] != "STAT" {
+			continue
+		}
+
+		if res[1] == "pid" || res[1] == "version" {
+			info[res[1]] = res[2]
+			continue
+		}
+
+		f, err := strconv.ParseFloat(res[2], 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing STAT %s: %w", res[1], err)
+		}
+		stats[res[1]] = f
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, err
+	}
+	return info, stats, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseStats(t *testing.T) {
+	const resp = "STAT pid 12345\r\n" +
+		"STAT version 1.2.3\r\n" +
+		"STAT curr_connections 7\r\n" +
+		"STAT uptime 123.5\r\n" +
+		"END\r\n"
+
+	info, stats, err := parseStats(strings.NewReader(resp))
+	if err != nil {
+		t.Fatalf("parseStats() error = %v", err)
+	}
+
+	if got, want := info["pid"], "12345"; got != want {
+		t.Errorf("info[pid] = %q, want %q", got, want)
+	}
+	if got, want := info["version"], "1.2.3"; got != want {
+		t.Errorf("info[version] = %q, want %q", got, want)
+	}
+	if got, want := stats["curr_connections"], 7.0; got != want {
+		t.Errorf("stats[curr_connections] = %v, want %v", got, want)
+	}
+	if got, want := stats["uptime"], 123.5; got != want {
+		t.Errorf("stats[uptime] = %v, want %v", got, want)
+	}
+}
+
+func TestParseStatsNonNumericValue(t *testing.T) {
+	const resp = "STAT curr_connections not-a-number\r\n" +
+		"END\r\n"
+
+	if _, _, err := parseStats(strings.NewReader(resp)); err == nil {
+		t.Fatal("parseStats() error = nil, want error for non-numeric STAT value")
+	}
+}
+
+func TestParseStatsMalformedLines(t *testing.T) {
+	const resp = "STAT\r\n" +
+		"STAT curr_connections\r\n" +
+		"garbage line that is not a STAT\r\n" +
+		"STAT uptime 42\r\n" +
+		"END\r\n"
+
+	info, stats, err := parseStats(strings.NewReader(resp))
+	if err != nil {
+		t.Fatalf("parseStats() error = %v", err)
+	}
+	if len(info) != 0 {
+		t.Errorf("info = %v, want empty", info)
+	}
+	if got, want := stats["uptime"], 42.0; got != want {
+		t.Errorf("stats[uptime] = %v, want %v", got, want)
+	}
+}
+
+func TestParseStatsEmptyResponse(t *testing.T) {
+	info, stats, err := parseStats(strings.NewReader("END\r\n"))
+	if err != nil {
+		t.Fatalf("parseStats() error = %v", err)
+	}
+	if len(info) != 0 || len(stats) != 0 {
+		t.Errorf("info = %v, stats = %v, want both empty", info, stats)
+	}
+}